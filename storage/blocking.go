@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"time"
+)
+
+// LoadOrWait returns the value stored for key if one is already present.
+// Otherwise it blocks until a StoreAndNotify call for key wakes it, or
+// until timeout elapses, whichever comes first. A timeout of zero means
+// wait indefinitely, matching the BLPOP/BRPOP convention. This is the
+// primitive BLPOP/BRPOP-style blocking commands are built on: multiple
+// callers may block on the same key, and a single StoreAndNotify wakes
+// all of them to race for the value.
+func (m *GenericConcurrentMap) LoadOrWait(key string, timeout time.Duration) (GCMType, bool) {
+	shard := m.shardFor(key)
+	waitForever := timeout == 0
+	deadline := m.Now().Add(timeout)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	for {
+		if e, ok := shard.items[key]; ok && !e.expired(m.Now()) {
+			return e.value, true
+		}
+
+		var timer *time.Timer
+		if !waitForever {
+			remaining := deadline.Sub(m.Now())
+			if remaining <= 0 {
+				return nil, false
+			}
+			// sync.Cond has no built-in wait-with-timeout, so a timer
+			// wakes the waiter via Broadcast if no real notification
+			// arrives first. The loop above re-checks the map (and the
+			// deadline) on every wake, so a stale timer firing after a
+			// real notification is harmless.
+			timer = time.AfterFunc(remaining, shard.cond.Broadcast)
+		}
+		shard.cond.Wait()
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// StoreAndNotify sets the value for key and wakes every caller currently
+// blocked on it in LoadOrWait, so they can race to observe the new
+// value.
+func (m *GenericConcurrentMap) StoreAndNotify(key string, v GCMType) {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	shard.items[key] = mapEntry{value: v}
+	shard.mu.Unlock()
+	shard.cond.Broadcast()
+}
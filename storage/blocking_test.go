@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadOrWaitReturnsExistingValueImmediately(t *testing.T) {
+	m := NewGenericConcurrentMap()
+	defer m.Close()
+	m.Store("foo", GCMStringType{"bar"})
+
+	v, ok := m.LoadOrWait("foo", time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, GCMStringType{"bar"}, v)
+}
+
+func TestLoadOrWaitTimesOut(t *testing.T) {
+	m := NewGenericConcurrentMap()
+	defer m.Close()
+
+	start := time.Now()
+	_, ok := m.LoadOrWait("missing", 20*time.Millisecond)
+	assert.False(t, ok)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestLoadOrWaitWakesOnStoreAndNotify(t *testing.T) {
+	m := NewGenericConcurrentMap()
+	defer m.Close()
+	done := make(chan GCMType, 1)
+
+	go func() {
+		v, ok := m.LoadOrWait("foo", time.Second)
+		assert.True(t, ok)
+		done <- v
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	m.StoreAndNotify("foo", GCMStringType{"bar"})
+
+	select {
+	case v := <-done:
+		assert.Equal(t, GCMStringType{"bar"}, v)
+	case <-time.After(time.Second):
+		t.Fatal("LoadOrWait did not wake up after StoreAndNotify")
+	}
+}
+
+func TestLoadOrWaitTimeoutTracksInjectedClock(t *testing.T) {
+	m, clock := newMapWithFakeClock()
+	defer m.Close()
+
+	done := make(chan bool, 1)
+	go func() {
+		_, ok := m.LoadOrWait("missing", 10*time.Millisecond)
+		done <- ok
+	}()
+
+	// Real time passes well beyond the nominal timeout, but the
+	// injected clock hasn't moved, so LoadOrWait must still be blocked:
+	// its deadline/remaining math has to be driven by m.Now(), not the
+	// wall clock.
+	select {
+	case <-done:
+		t.Fatal("LoadOrWait timed out using the wall clock instead of m.Now()")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Advancing the injected clock past the deadline must let it time out.
+	clock.Advance(time.Hour)
+	select {
+	case ok := <-done:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("LoadOrWait did not notice the advanced clock")
+	}
+}
+
+func TestLoadOrWaitZeroTimeoutBlocksIndefinitely(t *testing.T) {
+	m := NewGenericConcurrentMap()
+	defer m.Close()
+	done := make(chan GCMType, 1)
+
+	go func() {
+		v, ok := m.LoadOrWait("foo", 0)
+		assert.True(t, ok)
+		done <- v
+	}()
+
+	// With no timeout, the waiter must still be blocked well past any
+	// timeout this test would otherwise use.
+	select {
+	case <-done:
+		t.Fatal("LoadOrWait with a zero timeout returned without a value")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	m.StoreAndNotify("foo", GCMStringType{"bar"})
+	select {
+	case v := <-done:
+		assert.Equal(t, GCMStringType{"bar"}, v)
+	case <-time.After(time.Second):
+		t.Fatal("LoadOrWait did not wake up after StoreAndNotify")
+	}
+}
+
+func TestLoadOrWaitWakesAllBlockedWaiters(t *testing.T) {
+	m := NewGenericConcurrentMap()
+	defer m.Close()
+	const waiters = 5
+	var wg sync.WaitGroup
+	results := make(chan bool, waiters)
+
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok := m.LoadOrWait("foo", time.Second)
+			results <- ok
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	m.StoreAndNotify("foo", GCMStringType{"bar"})
+	wg.Wait()
+	close(results)
+
+	for ok := range results {
+		assert.True(t, ok)
+	}
+}
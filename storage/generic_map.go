@@ -0,0 +1,270 @@
+// Package storage holds the data structures backing the mock Redis
+// keyspace: the concurrent map implementation and, over time, the
+// command-level synchronization primitives built on top of it.
+package storage
+
+import (
+	"hash/fnv"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// GCMType is the marker interface implemented by every value that can be
+// stored in a GenericConcurrentMap. Concrete Redis value types (strings,
+// integers, ...) wrap their underlying Go value and expose it via their
+// own typed GetValue, so callers type-switch on the concrete type to
+// recover it.
+type GCMType interface {
+	isGCMType()
+}
+
+// GCMStringType wraps a Redis string value.
+type GCMStringType struct {
+	Value string
+}
+
+func (s GCMStringType) isGCMType() {}
+
+// GetValue returns the wrapped string.
+func (s GCMStringType) GetValue() string {
+	return s.Value
+}
+
+// GCMIntegerType wraps a Redis integer value.
+type GCMIntegerType struct {
+	Value int
+}
+
+func (i GCMIntegerType) isGCMType() {}
+
+// GetValue returns the wrapped integer.
+func (i GCMIntegerType) GetValue() int {
+	return i.Value
+}
+
+// defaultShardMultiplier controls how many shards NewGenericConcurrentMap
+// creates per CPU when picking a default shard count.
+const defaultShardMultiplier = 4
+
+// mapEntry is what a shard actually stores for a key: the value plus
+// optional expiration metadata. hasDeadline distinguishes "no TTL" from
+// the zero time.Time so a key can't accidentally expire at the epoch.
+type mapEntry struct {
+	value       GCMType
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (e mapEntry) expired(now time.Time) bool {
+	return e.hasDeadline && now.After(e.deadline)
+}
+
+// mapShard is a single bucket of the sharded map: an independent map
+// guarded by its own RWMutex, so writers touching different shards never
+// contend with each other. cond lets LoadOrWait block on the shard's
+// mutex and StoreAndNotify wake every waiter in the shard.
+type mapShard struct {
+	mu    sync.RWMutex
+	items map[string]mapEntry
+	cond  *sync.Cond
+}
+
+// GenericConcurrentMap is a concurrency-safe string-keyed map, sharded
+// across N buckets to spread lock contention across writers instead of
+// serializing every Store/Load/Delete behind one global mutex.
+type GenericConcurrentMap struct {
+	shards    []*mapShard
+	shardMask uint32
+
+	// Now supplies the current time for TTL checks. It defaults to
+	// time.Now but can be overridden in tests to drive expiry
+	// deterministically without sleeping.
+	Now func() time.Time
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewGenericConcurrentMap creates a GenericConcurrentMap with a default
+// shard count: the next power of two at or above
+// runtime.NumCPU()*defaultShardMultiplier.
+func NewGenericConcurrentMap() *GenericConcurrentMap {
+	return NewGenericConcurrentMapWithShards(nextPowerOfTwo(uint32(runtime.NumCPU() * defaultShardMultiplier)))
+}
+
+// NewGenericConcurrentMapWithShards creates a GenericConcurrentMap with
+// exactly n shards and starts its active-eviction goroutine. n must be a
+// power of two so key routing can use a bitmask instead of a modulo.
+// The eviction goroutine runs whether or not any TTL is ever set, so
+// callers should call Close when done to stop it.
+func NewGenericConcurrentMapWithShards(n uint32) *GenericConcurrentMap {
+	if n == 0 || n&(n-1) != 0 {
+		panic("storage: shard count must be a power of two")
+	}
+	shards := make([]*mapShard, n)
+	for i := range shards {
+		shard := &mapShard{items: make(map[string]mapEntry)}
+		shard.cond = sync.NewCond(&shard.mu)
+		shards[i] = shard
+	}
+	m := &GenericConcurrentMap{
+		shards:    shards,
+		shardMask: n - 1,
+		Now:       time.Now,
+		done:      make(chan struct{}),
+	}
+	m.wg.Add(1)
+	go m.runActiveEviction()
+	return m
+}
+
+// Close stops the active-eviction goroutine and waits for it to exit.
+// It is safe to call more than once.
+func (m *GenericConcurrentMap) Close() {
+	m.closeOnce.Do(func() {
+		close(m.done)
+	})
+	m.wg.Wait()
+}
+
+// shardFor routes a key to its owning shard via FNV-1a, the same hash
+// family used by the bucketed concurrent maps this design is modeled on.
+func (m *GenericConcurrentMap) shardFor(key string) *mapShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return m.shards[h.Sum32()&m.shardMask]
+}
+
+// Store sets the value for key, creating or overwriting it, and clears
+// any TTL the key previously had.
+func (m *GenericConcurrentMap) Store(key string, v GCMType) {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	shard.items[key] = mapEntry{value: v}
+	shard.mu.Unlock()
+}
+
+// Load returns the value stored for key, and whether it was present. A
+// key whose TTL has elapsed is lazily evicted and reported as absent.
+func (m *GenericConcurrentMap) Load(key string) (GCMType, bool) {
+	e, ok := m.loadEntry(key)
+	if !ok {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// loadEntry returns the full entry (value plus TTL metadata) stored for
+// key, lazily evicting it first if its TTL has elapsed. Callers that
+// only need the value should use Load instead.
+func (m *GenericConcurrentMap) loadEntry(key string) (mapEntry, bool) {
+	shard := m.shardFor(key)
+	now := m.Now()
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	e, ok := shard.items[key]
+	if !ok {
+		return mapEntry{}, false
+	}
+	if e.expired(now) {
+		delete(shard.items, key)
+		return mapEntry{}, false
+	}
+	return e, true
+}
+
+// Delete removes key from the map and reports whether it was present.
+// An already-expired key is removed and reported as absent.
+func (m *GenericConcurrentMap) Delete(key string) bool {
+	shard := m.shardFor(key)
+	now := m.Now()
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	e, ok := shard.items[key]
+	delete(shard.items, key)
+	if !ok || e.expired(now) {
+		return false
+	}
+	return true
+}
+
+// Count returns the total number of live (non-expired) entries across
+// all shards.
+func (m *GenericConcurrentMap) Count() int {
+	n := 0
+	now := m.Now()
+	for _, shard := range m.shards {
+		shard.mu.RLock()
+		for _, e := range shard.items {
+			if !e.expired(now) {
+				n++
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	return n
+}
+
+// Keys returns a snapshot of all live keys currently in the map.
+func (m *GenericConcurrentMap) Keys() []string {
+	keys := make([]string, 0, m.Count())
+	now := m.Now()
+	for _, shard := range m.shards {
+		shard.mu.RLock()
+		for k, e := range shard.items {
+			if !e.expired(now) {
+				keys = append(keys, k)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	return keys
+}
+
+// IterCb calls cb for every live entry in the map. Each shard is locked
+// only for the duration of its own iteration, so cb must not call back
+// into the map or it may deadlock.
+func (m *GenericConcurrentMap) IterCb(cb func(key string, v GCMType)) {
+	now := m.Now()
+	for _, shard := range m.shards {
+		shard.mu.RLock()
+		for k, e := range shard.items {
+			if !e.expired(now) {
+				cb(k, e.value)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+}
+
+// Items returns a point-in-time snapshot of the whole map as a plain Go
+// map. Because shards are snapshotted one at a time, concurrent writers
+// can still mean the result is not a single atomic instant across the
+// entire map.
+func (m *GenericConcurrentMap) Items() map[string]GCMType {
+	items := make(map[string]GCMType, m.Count())
+	m.IterCb(func(k string, v GCMType) {
+		items[k] = v
+	})
+	return items
+}
+
+// nextPowerOfTwo rounds n up to the nearest power of two, returning 1 for
+// n == 0.
+func nextPowerOfTwo(n uint32) uint32 {
+	if n == 0 {
+		return 1
+	}
+	n--
+	n |= n >> 1
+	n |= n >> 2
+	n |= n >> 4
+	n |= n >> 8
+	n |= n >> 16
+	n++
+	return n
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// benchmarkConcurrentWrites spins up writers writers, each storing its own
+// set of keys, and measures total wall-clock throughput with b.N
+// iterations split across them.
+func benchmarkConcurrentWrites(b *testing.B, m *GenericConcurrentMap, writers int) {
+	var wg sync.WaitGroup
+	perWriter := b.N / writers
+	if perWriter == 0 {
+		perWriter = 1
+	}
+	b.ResetTimer()
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				key := "writer" + strconv.Itoa(w) + "-" + strconv.Itoa(i)
+				m.Store(key, GCMIntegerType{i})
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func BenchmarkConcurrentMapWrites_1Shard_8Writers(b *testing.B) {
+	m := NewGenericConcurrentMapWithShards(1)
+	defer m.Close()
+	benchmarkConcurrentWrites(b, m, 8)
+}
+
+func BenchmarkConcurrentMapWrites_DefaultShards_8Writers(b *testing.B) {
+	m := NewGenericConcurrentMap()
+	defer m.Close()
+	benchmarkConcurrentWrites(b, m, 8)
+}
+
+func BenchmarkConcurrentMapWrites_32Shards_8Writers(b *testing.B) {
+	m := NewGenericConcurrentMapWithShards(32)
+	defer m.Close()
+	benchmarkConcurrentWrites(b, m, 8)
+}
@@ -10,6 +10,7 @@ import (
 
 func TestConcurrentMapSingleClientStoreAndLoad(t *testing.T) {
 	m := NewGenericConcurrentMap()
+	defer m.Close()
 	m.Store("foo", GCMStringType{"bar"})
 	m.Store("foo2", GCMIntegerType{2})
 	val, ok := m.Load("foo")
@@ -34,6 +35,7 @@ func TestConcurrentMapSingleClientStoreAndLoad(t *testing.T) {
 
 func TestConcurrentSingleClientMapDelete(t *testing.T) {
 	m := NewGenericConcurrentMap()
+	defer m.Close()
 	m.Store("foo", GCMStringType{"bar"})
 	m.Store("foo2", GCMIntegerType{2})
 	ok := m.Delete("foo")
@@ -65,6 +67,7 @@ func TestConcurrentMapAccessMultipleClients(t *testing.T) {
 	// Single writer, 2 readers
 	// Ideas for this test are taken from https://golang.org/src/runtime/rwmutex_test.go
 	m := NewGenericConcurrentMap()
+	defer m.Close()
 	// Store initial value
 	m.Store("foo", GCMStringType{"omg"})
 
@@ -98,56 +101,67 @@ func TestConcurrentMapAccessMultipleClients(t *testing.T) {
 
 func TestConcurrentMapWriteMultipleWriters(t *testing.T) {
 	m := NewGenericConcurrentMap()
-	done := make(chan string)
-	c := make(chan string, 1)
-
-	// We need this variable to hold the first value that is written. Because goroutines
-	// can run concurrently, we don't know which write will succeed. By storing the return
-	// value from write, we know which value to compare against
-	var curr string
-
-	// Two concurrent writers. Any may win first because we are only waiting for one
+	defer m.Close()
+	done := make(chan string, 2)
+
+	// Two concurrent writers racing on the same key. We don't know which
+	// Store call wins, and the order their "done" signals arrive in is
+	// not the order their Stores actually completed in (a goroutine can
+	// be descheduled between the two), so we can't use that order to
+	// predict what Load will return afterwards. All we can assert is
+	// that both writes finished and the map now holds one of the two
+	// written values, consistently.
 	go writer(m, done, "foo", GCMStringType{"lol"})
 	go writer(m, done, "foo", GCMStringType{"lol2"})
-	curr = <-done
-	go reader(t, m, c, "foo")
-	assert.Equal(t, <-c, curr)
-	// If we now assert a reader, we may get lol or lol2, because we are not waiting on done.
-	// We have no way of knowing which one without the wait
-	curr = <-done
-	go reader(t, m, c, "foo")
-	assert.Equal(t, <-c, curr)
+	written := []string{<-done, <-done}
+
+	c := make(chan string, 1)
+	reader(t, m, c, "foo")
+	assert.Contains(t, written, <-c)
 }
 
 func TestConcurrentMapWriteAndDelete(t *testing.T) {
 	m := NewGenericConcurrentMap()
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	// If we schedule one after each other, it may fail.
-	// There is no guarantee that write will finish first
-	// Here we use a waitgroup to wait for counter to go to zero
-
-	// Run write first
+	defer m.Close()
+
+	// done tracks every goroutine this test spawns, so the test function
+	// does not return (and testify does not touch t from a goroutine)
+	// until all of their assertions have run.
+	var done sync.WaitGroup
+	done.Add(4)
+
+	// Run write first, then delete: the delete must observe the key. Use
+	// a key of its own so this ordering can't be contaminated by the
+	// independent delete-first-then-write goroutines below running
+	// concurrently against the same entry.
+	var writeFirst sync.WaitGroup
+	writeFirst.Add(1)
 	go func() {
-		m.Store("foo", GCMIntegerType{2})
-		wg.Done()
+		defer done.Done()
+		m.Store("foo1", GCMIntegerType{2})
+		writeFirst.Done()
 	}()
 	go func() {
-		wg.Wait()
-		// Waitgroup counter is now zero
-		ok := m.Delete("foo")
+		defer done.Done()
+		writeFirst.Wait()
+		ok := m.Delete("foo1")
 		assert.Equal(t, ok, true)
 	}()
-	wg.Add(1)
-	// Now run delete first
+
+	// Run delete first, then write: the delete must not observe the key.
+	var deleteFirst sync.WaitGroup
+	deleteFirst.Add(1)
 	go func() {
-		wg.Wait()
-		m.Store("foo", GCMIntegerType{2})
+		defer done.Done()
+		deleteFirst.Wait()
+		m.Store("foo2", GCMIntegerType{2})
 	}()
 	go func() {
-		ok := m.Delete("foo")
+		defer done.Done()
+		ok := m.Delete("foo2")
 		assert.Equal(t, ok, false)
-		wg.Done()
+		deleteFirst.Done()
 	}()
-}
\ No newline at end of file
+
+	done.Wait()
+}
@@ -0,0 +1,62 @@
+package storage
+
+import "sync"
+
+// refCountedLock is a mutex paired with a count of how many callers
+// currently hold or are waiting on it, so KeyLocker knows when it is
+// safe to remove the entry for a key.
+type refCountedLock struct {
+	mu       sync.Mutex
+	refCount int
+}
+
+// KeyLocker provides per-key mutual exclusion: commands operating on the
+// same key are serialized against each other, while commands on
+// different keys run concurrently. This lets a command dispatcher
+// preserve per-key ordering (e.g. two INCRs racing on the same key)
+// without falling back to a single global lock for every command. There
+// is no dispatcher in this repo yet to wire it into; see ExampleKeyLocker
+// for the intended Acquire/Release call pattern around a handler.
+type KeyLocker struct {
+	mu    sync.Mutex
+	locks map[string]*refCountedLock
+}
+
+// NewKeyLocker creates an empty KeyLocker.
+func NewKeyLocker() *KeyLocker {
+	return &KeyLocker{locks: make(map[string]*refCountedLock)}
+}
+
+// Acquire blocks until the caller holds exclusive access to key. Every
+// call must be paired with a matching Release.
+func (k *KeyLocker) Acquire(key string) {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &refCountedLock{}
+		k.locks[key] = l
+	}
+	l.refCount++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+}
+
+// Release releases the lock acquired by a prior Acquire(key) call. Once
+// the last holder releases, the entry for key is removed so the lock map
+// does not grow unbounded under key churn.
+func (k *KeyLocker) Release(key string) {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		k.mu.Unlock()
+		panic("storage: Release called for key with no outstanding Acquire: " + key)
+	}
+	l.refCount--
+	if l.refCount == 0 {
+		delete(k.locks, key)
+	}
+	k.mu.Unlock()
+
+	l.mu.Unlock()
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	m := NewGenericConcurrentMap()
+	defer m.Close()
+
+	m.Store("str", GCMStringType{"bar"})
+	m.Store("int", GCMIntegerType{42})
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.Snapshot(&buf))
+
+	restored := NewGenericConcurrentMap()
+	defer restored.Close()
+	assert.NoError(t, restored.Restore(&buf))
+
+	v, ok := restored.Load("str")
+	assert.True(t, ok)
+	assert.Equal(t, GCMStringType{"bar"}, v)
+
+	v, ok = restored.Load("int")
+	assert.True(t, ok)
+	assert.Equal(t, GCMIntegerType{42}, v)
+
+	assert.Equal(t, 2, restored.Count())
+}
+
+func TestRestoreOverwritesExistingKeys(t *testing.T) {
+	m := NewGenericConcurrentMap()
+	defer m.Close()
+	m.Store("foo", GCMStringType{"old"})
+
+	var buf bytes.Buffer
+	src := NewGenericConcurrentMap()
+	defer src.Close()
+	src.Store("foo", GCMStringType{"new"})
+	assert.NoError(t, src.Snapshot(&buf))
+
+	assert.NoError(t, m.Restore(&buf))
+
+	v, ok := m.Load("foo")
+	assert.True(t, ok)
+	assert.Equal(t, GCMStringType{"new"}, v)
+}
+
+func TestSnapshotOmitsExpiredEntries(t *testing.T) {
+	m, clock := newMapWithFakeClock()
+	defer m.Close()
+
+	m.StoreWithTTL("gone", GCMStringType{"bar"}, 0)
+	clock.Advance(1)
+	m.Store("here", GCMStringType{"baz"})
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.Snapshot(&buf))
+
+	restored := NewGenericConcurrentMap()
+	defer restored.Close()
+	assert.NoError(t, restored.Restore(&buf))
+
+	assert.Equal(t, 1, restored.Count())
+	_, ok := restored.Load("gone")
+	assert.False(t, ok)
+}
+
+func TestSnapshotRestorePreservesTTL(t *testing.T) {
+	m, clock := newMapWithFakeClock()
+	defer m.Close()
+	m.StoreWithTTL("foo", GCMStringType{"bar"}, time.Minute)
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.Snapshot(&buf))
+
+	restored, restoredClock := newMapWithFakeClock()
+	defer restored.Close()
+	restoredClock.Set(clock.Now())
+	assert.NoError(t, restored.Restore(&buf))
+
+	ttl, ok := restored.TTL("foo")
+	assert.True(t, ok)
+	assert.True(t, ttl <= time.Minute && ttl > 0)
+
+	restoredClock.Advance(2 * time.Minute)
+	_, ok = restored.Load("foo")
+	assert.False(t, ok)
+}
@@ -0,0 +1,97 @@
+package storage
+
+import "time"
+
+const (
+	// activeEvictionInterval is how often the background eviction
+	// goroutine sweeps shards for expired keys.
+	activeEvictionInterval = 100 * time.Millisecond
+	// activeEvictionSampleSize bounds how many entries a single sweep
+	// inspects per shard, mirroring Redis's bounded random sampling
+	// instead of scanning the whole keyspace on every tick.
+	activeEvictionSampleSize = 20
+)
+
+// StoreWithTTL sets the value for key, creating or overwriting it, and
+// marks it to expire after ttl elapses.
+func (m *GenericConcurrentMap) StoreWithTTL(key string, v GCMType, ttl time.Duration) {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	shard.items[key] = mapEntry{value: v, deadline: m.Now().Add(ttl), hasDeadline: true}
+	shard.mu.Unlock()
+}
+
+// Expire sets key to expire after ttl elapses, overwriting any TTL it
+// already had. It reports false if key does not exist (or has already
+// lazily expired).
+func (m *GenericConcurrentMap) Expire(key string, ttl time.Duration) bool {
+	shard := m.shardFor(key)
+	now := m.Now()
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	e, ok := shard.items[key]
+	if !ok || e.expired(now) {
+		delete(shard.items, key)
+		return false
+	}
+	e.deadline = now.Add(ttl)
+	e.hasDeadline = true
+	shard.items[key] = e
+	return true
+}
+
+// TTL returns the remaining time-to-live for key. ok is false if key
+// does not exist, has already expired, or has no TTL set.
+func (m *GenericConcurrentMap) TTL(key string) (time.Duration, bool) {
+	shard := m.shardFor(key)
+	now := m.Now()
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	e, ok := shard.items[key]
+	if !ok || e.expired(now) || !e.hasDeadline {
+		return 0, false
+	}
+	return e.deadline.Sub(now), true
+}
+
+// runActiveEviction periodically sweeps every shard for expired keys
+// until Close is called. It is started by NewGenericConcurrentMapWithShards
+// and terminates cleanly via the done channel, so no goroutine leaks past
+// Close.
+func (m *GenericConcurrentMap) runActiveEviction() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(activeEvictionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			m.evictExpiredSample()
+		}
+	}
+}
+
+// evictExpiredSample inspects up to activeEvictionSampleSize entries per
+// shard and deletes the ones that have expired.
+func (m *GenericConcurrentMap) evictExpiredSample() {
+	now := m.Now()
+	for _, shard := range m.shards {
+		shard.mu.Lock()
+		sampled := 0
+		for k, e := range shard.items {
+			if sampled >= activeEvictionSampleSize {
+				break
+			}
+			sampled++
+			if e.expired(now) {
+				delete(shard.items, k)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
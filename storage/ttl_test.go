@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock lets TTL tests advance time deterministically instead of
+// sleeping. It is safe for concurrent use since it's shared between the
+// test goroutine and the map's own background eviction/blocking
+// goroutines.
+type fakeClock struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+func newMapWithFakeClock() (*GenericConcurrentMap, *fakeClock) {
+	m := NewGenericConcurrentMap()
+	clock := &fakeClock{now: time.Now()}
+	m.Now = clock.Now
+	return m, clock
+}
+
+func TestStoreWithTTLLazilyExpiresOnLoad(t *testing.T) {
+	m, clock := newMapWithFakeClock()
+	defer m.Close()
+
+	m.StoreWithTTL("foo", GCMStringType{"bar"}, time.Second)
+
+	v, ok := m.Load("foo")
+	assert.True(t, ok)
+	assert.Equal(t, GCMStringType{"bar"}, v)
+
+	clock.Advance(2 * time.Second)
+
+	_, ok = m.Load("foo")
+	assert.False(t, ok)
+}
+
+func TestStoreWithTTLLazilyExpiresOnDelete(t *testing.T) {
+	m, clock := newMapWithFakeClock()
+	defer m.Close()
+
+	m.StoreWithTTL("foo", GCMStringType{"bar"}, time.Second)
+	clock.Advance(2 * time.Second)
+
+	ok := m.Delete("foo")
+	assert.False(t, ok)
+}
+
+func TestExpireSetsTTLOnExistingKey(t *testing.T) {
+	m, clock := newMapWithFakeClock()
+	defer m.Close()
+
+	m.Store("foo", GCMStringType{"bar"})
+	ok := m.Expire("foo", time.Second)
+	assert.True(t, ok)
+
+	ttl, ok := m.TTL("foo")
+	assert.True(t, ok)
+	assert.True(t, ttl <= time.Second && ttl > 0)
+
+	clock.Advance(2 * time.Second)
+	_, ok = m.Load("foo")
+	assert.False(t, ok)
+}
+
+func TestExpireReturnsFalseForMissingKey(t *testing.T) {
+	m, _ := newMapWithFakeClock()
+	defer m.Close()
+
+	ok := m.Expire("missing", time.Second)
+	assert.False(t, ok)
+}
+
+func TestTTLReturnsFalseWithoutDeadline(t *testing.T) {
+	m, _ := newMapWithFakeClock()
+	defer m.Close()
+
+	m.Store("foo", GCMStringType{"bar"})
+	_, ok := m.TTL("foo")
+	assert.False(t, ok)
+}
+
+func TestActiveEvictionRemovesExpiredKeys(t *testing.T) {
+	m, clock := newMapWithFakeClock()
+	defer m.Close()
+
+	m.StoreWithTTL("foo", GCMStringType{"bar"}, time.Millisecond)
+	clock.Advance(2 * time.Millisecond)
+
+	// Count and Load already hide expired entries, so look at the shard
+	// itself to see whether the background sweep removed the key.
+	shard := m.shardFor("foo")
+	assert.Eventually(t, func() bool {
+		shard.mu.RLock()
+		defer shard.mu.RUnlock()
+		_, present := shard.items["foo"]
+		return !present
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCloseStopsActiveEvictionGoroutine(t *testing.T) {
+	m, _ := newMapWithFakeClock()
+	m.Close()
+	// A second Close must not panic or block.
+	m.Close()
+}